@@ -1,17 +1,14 @@
-//go:build linux || darwin
-// +build linux darwin
-
 package main
 
 import (
+	"bytes"
 	"fmt"
-	_ "os"
+	"os"
 	"path/filepath"
-	"runtime"
 	"strings"
-	"unsafe"
+	"sync"
 
-	"golang.org/x/sys/unix"
+	"github.com/poku-e/makeChaff/vfs"
 )
 
 // ANSI colors
@@ -42,17 +39,36 @@ func renderBar(written, total uint64) string {
 	return "[" + strings.Repeat("=", filled) + strings.Repeat(" ", barWidth-filled) + "]"
 }
 
-func printProgress(prefix string, written, total uint64) {
+var progressMu sync.Mutex
+
+// printProgress renders a progress bar for one unit of work. rows is the
+// number of output lines reserved for concurrent workers sharing the
+// terminal (1 for plain sequential progress); slot identifies which of
+// those reserved lines belongs to this caller. When rows > 1, printProgress
+// uses ANSI cursor moves to redraw only its own line, leaving the other
+// workers' lines undisturbed.
+func printProgress(prefix string, written, total uint64, slot, rows int) {
 	bar := renderBar(written, total)
 	percent := 0.0
 	if total > 0 {
 		percent = (float64(written) / float64(total)) * 100.0
 	}
-	msg := fmt.Sprintf("\r%s %s %6.2f%%", prefix, bar, percent)
-	unix.Write(unix.Stdout, []byte(msg))
-	if written >= total {
-		unix.Write(unix.Stdout, []byte("\n"))
+
+	progressMu.Lock()
+	defer progressMu.Unlock()
+
+	if rows <= 1 {
+		msg := fmt.Sprintf("\r%s %s %6.2f%%", prefix, bar, percent)
+		os.Stdout.WriteString(msg)
+		if written >= total {
+			os.Stdout.WriteString("\n")
+		}
+		return
 	}
+
+	up := rows - slot
+	msg := fmt.Sprintf("\033[%dA\r\033[2K%s %s %6.2f%%\033[%dB\r", up, prefix, bar, percent, up)
+	os.Stdout.WriteString(msg)
 }
 
 func formatBytes(b uint64) string {
@@ -69,40 +85,10 @@ func formatBytes(b uint64) string {
 }
 
 // ================================================================
-// System Utilities
+// File Generation
 // ================================================================
 
-func getAvailableSpace(path string) (uint64, error) {
-	var stat unix.Statfs_t
-	if err := unix.Statfs(path, &stat); err != nil {
-		return 0, err
-	}
-	return stat.Bavail * uint64(stat.Bsize), nil
-}
-
-func readUrandom(buf []byte) error {
-	fd, err := unix.Open("/dev/urandom", unix.O_RDONLY, 0)
-	if err != nil {
-		return err
-	}
-	defer unix.Close(fd)
-
-	total := 0
-	for total < len(buf) {
-		n, err := unix.Read(fd, buf[total:])
-		if err != nil {
-			return err
-		}
-		total += n
-	}
-	return nil
-}
-
-// ================================================================
-// File Generation (Direct Syscalls)
-// ================================================================
-
-func generateChaffFile(filename string, sizeMB int64, available uint64) (uint64, uint64, error) {
+func generateChaffFile(fsys vfs.FS, filename string, sizeMB int64, available uint64, slot, rows int, rnd RandSource) (uint64, uint64, error) {
 	sizeBytes := uint64(sizeMB) * 1024 * 1024
 	if sizeBytes > available {
 		sizeBytes = available
@@ -111,11 +97,11 @@ func generateChaffFile(filename string, sizeMB int64, available uint64) (uint64,
 		return available, 0, nil
 	}
 
-	fd, err := unix.Open(filename, unix.O_CREAT|unix.O_WRONLY|unix.O_TRUNC, 0644)
+	f, err := fsys.Create(filename, 0644)
 	if err != nil {
 		return available, 0, err
 	}
-	defer unix.Close(fd)
+	defer f.Close()
 
 	const chunkSize = 1024 * 1024
 	buf := make([]byte, chunkSize)
@@ -129,101 +115,102 @@ func generateChaffFile(filename string, sizeMB int64, available uint64) (uint64,
 		if remain < uint64(n) {
 			n = int(remain)
 		}
-		if err := readUrandom(buf[:n]); err != nil {
+		if err := rnd.Fill(buf[:n]); err != nil {
 			return available - written, written, err
 		}
-		w, err := unix.Write(fd, buf[:n])
+		w, err := f.Write(buf[:n])
+		written += uint64(w)
 		if err != nil {
 			return available - written, written, err
 		}
-		written += uint64(w)
-		printProgress(prefix, written, sizeBytes)
+		printProgress(prefix, written, sizeBytes, slot, rows)
 	}
 
-	unix.Fsync(fd)
+	f.Fsync()
 	fmt.Printf("%s %s\n", colorize("Created:", ColorGreen), filename)
 	return available - sizeBytes, written, nil
 }
 
 // ================================================================
-// Shredding Logic (Low-Level)
+// Shredding Logic
 // ================================================================
 
-func shredFile(path string) error {
-	fd, err := unix.Open(path, unix.O_RDWR, 0)
+func shredFile(fsys vfs.FS, path string, pattern ShredPattern, rnd RandSource) error {
+	f, err := fsys.Open(path)
 	if err != nil {
-		unix.Unlink(path)
+		fsys.Unlink(path)
 		return err
 	}
-	defer unix.Close(fd)
+	defer f.Close()
 
-	var st unix.Stat_t
-	if err := unix.Fstat(fd, &st); err != nil {
-		unix.Unlink(path)
+	st, err := f.Stat()
+	if err != nil {
+		fsys.Unlink(path)
 		return err
 	}
 
 	if st.Size == 0 {
-		unix.Unlink(path)
+		fsys.Unlink(path)
 		return nil
 	}
 
 	size := int(st.Size)
 	const chunkSize = 1024 * 1024
 	buf := make([]byte, chunkSize)
+	verifyBuf := make([]byte, chunkSize)
 	base := filepath.Base(path)
-	passes := []string{"0xFF", "0x00", "random"}
+	passes := pattern.Passes()
 
 	for i, pass := range passes {
-		var filled byte
-		switch pass {
-		case "0xFF":
-			filled = 0xFF
-			for i := range buf {
-				buf[i] = filled
-			}
-		case "0x00":
-			filled = 0x00
-			for i := range buf {
-				buf[i] = filled
-			}
-		case "random":
-			_ = readUrandom(buf)
+		if err := fillPass(buf, pass, rnd); err != nil {
+			return err
 		}
 
-		unix.Seek(fd, 0, 0)
+		if _, err := f.Seek(0, 0); err != nil {
+			return err
+		}
 		remaining := size
 		var written int
 
 		prefix := fmt.Sprintf("%s %s - %s:",
 			colorize("Shredding", ColorRed), base,
-			colorize(fmt.Sprintf("Pass %d (%s)", i+1, pass), ColorYellow))
+			colorize(fmt.Sprintf("Pass %d/%d (%s)", i+1, len(passes), pass.describe()), ColorYellow))
 
 		for remaining > 0 {
 			toWrite := chunkSize
 			if remaining < chunkSize {
 				toWrite = remaining
 			}
-			n, err := unix.Write(fd, buf[:toWrite])
+			n, err := f.Write(buf[:toWrite])
 			if err != nil {
 				return err
 			}
+
+			if pass.Verify {
+				if _, err := f.ReadAt(verifyBuf[:n], int64(written)); err != nil {
+					return fmt.Errorf("verifying pass %d of %s: %w", i+1, base, err)
+				}
+				if !bytes.Equal(verifyBuf[:n], buf[:n]) {
+					return fmt.Errorf("verify failed for pass %d of %s: storage did not retain written bytes", i+1, base)
+				}
+			}
+
 			remaining -= n
 			written += n
-			printProgress(prefix, uint64(written), uint64(size))
+			printProgress(prefix, uint64(written), uint64(size), 0, 1)
 		}
-		unix.Fsync(fd)
+		f.Fsync()
 	}
 
-	unix.Close(fd)
-	unix.Unlink(path)
+	f.Close()
+	fsys.Unlink(path)
 	fmt.Printf("%s %s\n", colorize("Shredded and removed:", ColorGreen), path)
 	return nil
 }
 
-func shredFiles(files []string) {
+func shredFiles(fsys vfs.FS, files []string, pattern ShredPattern, rnd RandSource) {
 	for _, f := range files {
-		if err := shredFile(f); err != nil {
+		if err := shredFile(fsys, f, pattern, rnd); err != nil {
 			fmt.Printf("%s %s: %v\n", colorize("Error shredding", ColorRed), f, err)
 		}
 	}
@@ -233,75 +220,47 @@ func shredFiles(files []string) {
 // TRIM/Discard Handling
 // ================================================================
 
-func runTrim(path string) error {
+func runTrim(fsys vfs.FS, path string) error {
 	fmt.Println()
-	switch runtime.GOOS {
-	case "linux":
-		fmt.Println("Detected Linux: attempting direct fstrim syscall...")
-		const FITRIM = 0x00009409
-		type fstrimRange struct {
-			Start  uint64
-			Len    uint64
-			Minlen uint64
-		}
-		fd, err := unix.Open(path, unix.O_RDONLY, 0)
-		if err != nil {
-			return err
-		}
-		defer unix.Close(fd)
-
-		rng := fstrimRange{Start: 0, Len: ^uint64(0), Minlen: 0}
-		_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), FITRIM, uintptr(unsafe.Pointer(&rng)))
-		if errno != 0 {
-			return fmt.Errorf("ioctl FITRIM failed: %v", errno)
-		}
-		fmt.Println("TRIM operation completed successfully.")
-		return nil
-
-	case "darwin":
-		fmt.Println("Detected macOS: TRIM/discard must be performed manually.")
-		fmt.Printf("Suggested: sudo diskutil secureErase freespace 0 %s\n", path)
-		return nil
-
-	default:
-		fmt.Printf("TRIM/discard not supported for OS: %s\n", runtime.GOOS)
-		return nil
+	fmt.Printf("Attempting TRIM/discard on %s...\n", path)
+	if err := fsys.Trim(path); err != nil {
+		return err
 	}
+	fmt.Println("TRIM operation completed successfully.")
+	return nil
 }
 
 // ================================================================
-// Entry Point
+// Pipeline Driver
 // ================================================================
 
-func main() {
-	outputDir := "./chaff"
-	fileSizeMB := int64(100)
-	filePrefix := "chaff_"
-
-	fmt.Println(colorize("=== Low-Level Chaff Generator ===", ColorCyan))
-	fmt.Println(colorize("WARNING: This will fill your disk with random data!", ColorYellow))
-	abs, _ := filepath.Abs(outputDir)
-	fmt.Printf("%s %s\n", colorize("Target directory:", ColorCyan), abs)
-	fmt.Printf("%s %d MB\n", colorize("File size:", ColorCyan), fileSizeMB)
-	fmt.Println()
-
-	fmt.Print(colorize("Are you sure you want to continue? (yes/NO): ", ColorRed))
-	var resp string
-	fmt.Scanln(&resp)
-	if resp != "yes" {
-		fmt.Println(colorize("Operation cancelled.", ColorGreen))
-		return
+// fill generates chaff files under outputDir until free space is exhausted
+// (or drops to reserveBytes), then immediately shreds everything it created.
+func fill(fsys vfs.FS, outputDir string, fileSizeMB int64, filePrefix string, reserveBytes uint64, pattern ShredPattern, newRand RandFactory) error {
+	rnd, err := newRand()
+	if err != nil {
+		return fmt.Errorf("initializing random source: %w", err)
 	}
+	defer rnd.Close()
 
-	unix.Mkdir(outputDir, 0755)
+	if err := fsys.Mkdir(outputDir, 0755); err != nil && !os.IsExist(err) {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
 
-	available, err := getAvailableSpace(outputDir)
+	sf, err := fsys.Statfs(outputDir)
 	if err != nil {
-		fmt.Printf("%s %v\n", colorize("Error getting disk space:", ColorRed), err)
-		return
+		return fmt.Errorf("getting disk space: %w", err)
+	}
+	total := sf.AvailableBytes
+	var available uint64
+	if total > reserveBytes {
+		available = total - reserveBytes
 	}
 
 	fmt.Printf("\n%s %s\n", colorize("Starting with", ColorCyan), formatBytes(available))
+	if reserveBytes > 0 {
+		fmt.Printf("%s %s\n", colorize("Reserved:", ColorCyan), formatBytes(reserveBytes))
+	}
 	fmt.Println(colorize("Generating chaff files...", ColorCyan))
 
 	fileCount := 0
@@ -312,7 +271,7 @@ func main() {
 		if available < 10*1024*1024 {
 			fmt.Println(colorize("Less than 10MB remaining, final small file...", ColorYellow))
 			final := filepath.Join(outputDir, fmt.Sprintf("%sFINAL.dat", filePrefix))
-			rem, written, err := generateChaffFile(final, 1, available)
+			rem, written, err := generateChaffFile(fsys, final, 1, available, 0, 1, rnd)
 			if err == nil && written > 0 {
 				created = append(created, final)
 			}
@@ -320,7 +279,7 @@ func main() {
 			break
 		}
 
-		rem, written, err := generateChaffFile(filename, fileSizeMB, available)
+		rem, written, err := generateChaffFile(fsys, filename, fileSizeMB, available, 0, 1, rnd)
 		if err != nil {
 			fmt.Printf("%s %s: %v\n", colorize("Error creating", ColorRed), filename, err)
 			fileCount++
@@ -340,29 +299,13 @@ func main() {
 	fmt.Printf("\n%s\n", colorize("=== Generation Complete ===", ColorCyan))
 	fmt.Printf("%s %d\n", colorize("Files created:", ColorCyan), fileCount)
 
-	finalSpace, _ := getAvailableSpace(outputDir)
-	fmt.Printf("%s %s\n", colorize("Space before shredding:", ColorCyan), formatBytes(finalSpace))
-
-	if len(created) > 0 {
-		fmt.Println()
-		fmt.Println(colorize("Shredding chaff files...", ColorRed))
-		shredFiles(created)
-	} else {
+	if len(created) == 0 {
 		fmt.Println(colorize("No files to shred.", ColorYellow))
+		return nil
 	}
 
-	finalAfter, _ := getAvailableSpace(outputDir)
-	fmt.Printf("%s %s\n", colorize("Final available space:", ColorCyan), formatBytes(finalAfter))
-
-	fmt.Print("\nAttempt TRIM/discard? (yes/NO): ")
-	fmt.Scanln(&resp)
-	if resp == "yes" {
-		if err := runTrim(outputDir); err != nil {
-			fmt.Printf("TRIM failed: %v\n", err)
-		}
-	} else {
-		fmt.Println("Skipping TRIM/discard step.")
-	}
-
-	fmt.Printf("\n%s\n", colorize("=== Operation Complete ===", ColorGreen))
+	fmt.Println()
+	fmt.Println(colorize("Shredding chaff files...", ColorRed))
+	shredFiles(fsys, created, pattern, rnd)
+	return nil
 }
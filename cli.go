@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"github.com/poku-e/makeChaff/platform"
+	"github.com/poku-e/makeChaff/vfs"
+)
+
+const progName = "makechaff"
+
+func printUsage() {
+	fmt.Fprintf(os.Stderr, `Usage: %s <command> [flags]
+
+Commands:
+  fill    Fill free space with chaff files, then shred them
+  shred   Securely overwrite and remove files
+  trim    Issue a TRIM/discard on a directory's filesystem
+
+Run '%s <command> --help' for flags specific to a command.
+`, progName, progName)
+}
+
+// confirm prompts the user with prompt and returns true if they answered
+// "yes", or unconditionally if skip (the --yes flag) is set.
+func confirm(prompt string, skip bool) bool {
+	if skip {
+		return true
+	}
+	fmt.Print(colorize(prompt, ColorRed))
+	resp, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(resp) == "yes"
+}
+
+func cmdFill(fsys vfs.FS, pfs platform.FS, args []string) error {
+	flags := pflag.NewFlagSet("fill", pflag.ExitOnError)
+	dir := flags.StringP("dir", "d", "./chaff", "directory to fill with chaff files")
+	sizeMB := flags.Int64P("size", "s", 100, "size of each chaff file in MB")
+	prefix := flags.StringP("prefix", "p", "chaff_", "filename prefix for generated chaff files")
+	reserveBytes := flags.Uint64("reserve-bytes", 0, "stop filling once free space drops below this many bytes")
+	concurrency := flags.IntP("concurrency", "c", runtime.NumCPU(), "number of chaff files to write in parallel")
+	patternName := flags.String("pattern", "dod3", "shred pattern to apply to finished chaff files: single, dod3, dod7, gutmann")
+	randName := flags.String("rand", "chacha20", "random source for chaff/shred data: urandom, chacha20")
+	rekeyGiB := flags.Uint64("rekey-gib", 16, "re-key the chacha20 rand source every N GiB of output")
+	yes := flags.BoolP("yes", "y", false, "skip the confirmation prompt")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	pattern, err := patternByName(*patternName)
+	if err != nil {
+		return err
+	}
+	if _, ok := randSourceNames[*randName]; !ok {
+		return fmt.Errorf("unknown rand source %q (choices: urandom, chacha20)", *randName)
+	}
+	newRand := func() (RandSource, error) { return newRandSource(pfs, *randName, *rekeyGiB*1024*1024*1024) }
+
+	abs, _ := filepath.Abs(*dir)
+	fmt.Println(colorize("=== Low-Level Chaff Generator ===", ColorCyan))
+	fmt.Println(colorize("WARNING: This will fill your disk with random data!", ColorYellow))
+	fmt.Printf("%s %s\n", colorize("Target directory:", ColorCyan), abs)
+	fmt.Printf("%s %d MB\n", colorize("File size:", ColorCyan), *sizeMB)
+	fmt.Println()
+
+	if !confirm("Are you sure you want to continue? (yes/NO): ", *yes) {
+		fmt.Println(colorize("Operation cancelled.", ColorGreen))
+		return nil
+	}
+
+	if *concurrency <= 1 {
+		return fill(fsys, *dir, *sizeMB, *prefix, *reserveBytes, pattern, newRand)
+	}
+	return fillConcurrent(fsys, *dir, *sizeMB, *prefix, *reserveBytes, *concurrency, pattern, newRand)
+}
+
+func cmdShred(fsys vfs.FS, pfs platform.FS, args []string) error {
+	flags := pflag.NewFlagSet("shred", pflag.ExitOnError)
+	patternName := flags.String("pattern", "dod3", "shred pattern to apply: single, dod3, dod7, gutmann")
+	randName := flags.String("rand", "chacha20", "random source for shred passes: urandom, chacha20")
+	rekeyGiB := flags.Uint64("rekey-gib", 16, "re-key the chacha20 rand source every N GiB of output")
+	yes := flags.BoolP("yes", "y", false, "skip the confirmation prompt")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	pattern, err := patternByName(*patternName)
+	if err != nil {
+		return err
+	}
+
+	files := flags.Args()
+	if len(files) == 0 {
+		return fmt.Errorf("shred requires at least one file path")
+	}
+
+	if !confirm(fmt.Sprintf("Shred %d file(s)? (yes/NO): ", len(files)), *yes) {
+		fmt.Println(colorize("Operation cancelled.", ColorGreen))
+		return nil
+	}
+
+	rnd, err := newRandSource(pfs, *randName, *rekeyGiB*1024*1024*1024)
+	if err != nil {
+		return err
+	}
+	defer rnd.Close()
+
+	shredFiles(fsys, files, pattern, rnd)
+	return nil
+}
+
+func cmdTrim(fsys vfs.FS, args []string) error {
+	flags := pflag.NewFlagSet("trim", pflag.ExitOnError)
+	yes := flags.BoolP("yes", "y", false, "skip the confirmation prompt")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	dir := "./chaff"
+	if flags.NArg() > 0 {
+		dir = flags.Arg(0)
+	}
+
+	if !confirm(fmt.Sprintf("Attempt TRIM/discard on %s? (yes/NO): ", dir), *yes) {
+		fmt.Println("Skipping TRIM/discard step.")
+		return nil
+	}
+
+	return runTrim(fsys, dir)
+}
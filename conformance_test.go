@@ -0,0 +1,241 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/poku-e/makeChaff/platform"
+	"github.com/poku-e/makeChaff/vfs"
+)
+
+// integration, when set, also runs the conformance suite against a real
+// UnixFS, so behavior verified against MemFS is checked for parity against
+// an actual disk too (go test ./... -integration).
+var integration = flag.Bool("integration", false, "also run the conformance suite against the real UnixFS")
+
+// errAfterRandSource is a RandSource that serves zeroed bytes while
+// remaining covers the request, and fails any Fill asking for more than
+// remaining, for exercising
+// how generateChaffFile/shredFile propagate a failing random source (e.g. a
+// /dev/urandom read error).
+type errAfterRandSource struct {
+	remaining int
+}
+
+func (s *errAfterRandSource) Fill(buf []byte) error {
+	if len(buf) > s.remaining {
+		return fmt.Errorf("simulated random source failure")
+	}
+	for i := range buf {
+		buf[i] = 0
+	}
+	s.remaining -= len(buf)
+	return nil
+}
+
+func (s *errAfterRandSource) Close() error { return nil }
+
+// shortWriteFS wraps a vfs.FS, capping every Write to at most max bytes, so
+// callers can't assume a single Write always satisfies the whole request.
+type shortWriteFS struct {
+	vfs.FS
+	max int
+}
+
+func (s shortWriteFS) Create(path string, mode uint32) (vfs.File, error) {
+	f, err := s.FS.Create(path, mode)
+	if err != nil {
+		return nil, err
+	}
+	return shortWriteFile{File: f, max: s.max}, nil
+}
+
+func (s shortWriteFS) Open(path string) (vfs.File, error) {
+	f, err := s.FS.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return shortWriteFile{File: f, max: s.max}, nil
+}
+
+type shortWriteFile struct {
+	vfs.File
+	max int
+}
+
+func (f shortWriteFile) Write(buf []byte) (int, error) {
+	if len(buf) > f.max {
+		buf = buf[:f.max]
+	}
+	return f.File.Write(buf)
+}
+
+// corruptingFS wraps a vfs.FS whose reads silently don't match what was
+// written, simulating storage that didn't retain a pass, to exercise
+// ShredPattern's verify step.
+type corruptingFS struct{ vfs.FS }
+
+func (c corruptingFS) Create(path string, mode uint32) (vfs.File, error) {
+	f, err := c.FS.Create(path, mode)
+	if err != nil {
+		return nil, err
+	}
+	return corruptingFile{File: f}, nil
+}
+
+func (c corruptingFS) Open(path string) (vfs.File, error) {
+	f, err := c.FS.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return corruptingFile{File: f}, nil
+}
+
+type corruptingFile struct{ vfs.File }
+
+func (f corruptingFile) ReadAt(buf []byte, offset int64) (int, error) {
+	n, err := f.File.ReadAt(buf, offset)
+	if n > 0 {
+		buf[0] ^= 0xFF
+	}
+	return n, err
+}
+
+// conformanceImpl names one vfs.FS under test, so failures report which
+// implementation broke, and lets individual subtests opt a real disk out of
+// checks that assume deterministic in-memory behavior (e.g. a specific
+// capacity).
+type conformanceImpl struct {
+	name  string
+	real  bool
+	newFS func(t *testing.T, capacity uint64) vfs.FS
+}
+
+func conformanceImpls() []conformanceImpl {
+	impls := []conformanceImpl{
+		{name: "MemFS", newFS: func(t *testing.T, capacity uint64) vfs.FS {
+			return vfs.NewMemFS(capacity)
+		}},
+	}
+	if *integration {
+		impls = append(impls, conformanceImpl{name: "UnixFS", real: true, newFS: func(t *testing.T, capacity uint64) vfs.FS {
+			dir := t.TempDir()
+			cwd, err := os.Getwd()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chdir(dir); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { os.Chdir(cwd) })
+			return vfs.NewUnixFS(platform.New())
+		}})
+	}
+	return impls
+}
+
+// TestConformance runs the same behavioral checks against every vfs.FS
+// implementation, so MemFS stays faithful to how the real disk behaves.
+func TestConformance(t *testing.T) {
+	for _, impl := range conformanceImpls() {
+		impl := impl
+		t.Run(impl.name, func(t *testing.T) {
+			t.Run("CapacityExhaustionMidWrite", func(t *testing.T) { testCapacityExhaustion(t, impl) })
+			t.Run("RandSourceError", func(t *testing.T) { testRandSourceError(t, impl) })
+			t.Run("ShortWrites", func(t *testing.T) { testShortWrites(t, impl) })
+			t.Run("UnlinkAfterShred", func(t *testing.T) { testUnlinkAfterShred(t, impl) })
+			t.Run("VerifyMismatch", func(t *testing.T) { testVerifyMismatch(t, impl) })
+			t.Run("Trim", func(t *testing.T) { testTrim(t, impl) })
+		})
+	}
+}
+
+func testCapacityExhaustion(t *testing.T, impl conformanceImpl) {
+	if impl.real {
+		t.Skip("a real disk can't be sized down to a deterministic capacity")
+	}
+	fsys := impl.newFS(t, 3*1024*1024)
+	rnd := &errAfterRandSource{remaining: 1 << 30}
+
+	_, written, err := generateChaffFile(fsys, "chaff.dat", 5, 5*1024*1024, 0, 1, rnd)
+	if !errors.Is(err, vfs.ErrOutOfSpace) {
+		t.Fatalf("generateChaffFile error = %v, want vfs.ErrOutOfSpace", err)
+	}
+	if written != 3*1024*1024 {
+		t.Fatalf("written = %d, want exactly the 3 MiB capacity", written)
+	}
+}
+
+func testRandSourceError(t *testing.T, impl conformanceImpl) {
+	fsys := impl.newFS(t, 10*1024*1024)
+	rnd := &errAfterRandSource{remaining: 512 * 1024} // less than one chunk
+
+	_, written, err := generateChaffFile(fsys, "chaff.dat", 2, 2*1024*1024, 0, 1, rnd)
+	if err == nil {
+		t.Fatal("expected an error once the random source fails")
+	}
+	if written != 0 {
+		t.Fatalf("written = %d, want 0 since the first chunk's Fill already failed", written)
+	}
+}
+
+func testShortWrites(t *testing.T, impl conformanceImpl) {
+	fsys := shortWriteFS{FS: impl.newFS(t, 10*1024*1024), max: 64 * 1024}
+	rnd := &errAfterRandSource{remaining: 1 << 30}
+
+	_, written, err := generateChaffFile(fsys, "chaff.dat", 1, 1024*1024, 0, 1, rnd)
+	if err != nil {
+		t.Fatalf("generateChaffFile: %v", err)
+	}
+	if written != 1024*1024 {
+		t.Fatalf("written = %d, want the full 1 MiB despite writes capped at 64 KiB", written)
+	}
+}
+
+func testUnlinkAfterShred(t *testing.T, impl conformanceImpl) {
+	fsys := impl.newFS(t, 10*1024*1024)
+	rnd := &errAfterRandSource{remaining: 1 << 30}
+	const filename = "chaff.dat"
+
+	if _, _, err := generateChaffFile(fsys, filename, 1, 1024*1024, 0, 1, rnd); err != nil {
+		t.Fatalf("generateChaffFile: %v", err)
+	}
+	if err := shredFile(fsys, filename, singlePattern{}, rnd); err != nil {
+		t.Fatalf("shredFile: %v", err)
+	}
+	if _, err := fsys.Open(filename); err == nil {
+		t.Fatal("shredFile left the file behind")
+	}
+}
+
+func testVerifyMismatch(t *testing.T, impl conformanceImpl) {
+	fsys := corruptingFS{FS: impl.newFS(t, 10*1024*1024)}
+	rnd := &errAfterRandSource{remaining: 1 << 30}
+	const filename = "chaff.dat"
+
+	if _, _, err := generateChaffFile(fsys, filename, 1, 1024*1024, 0, 1, rnd); err != nil {
+		t.Fatalf("generateChaffFile: %v", err)
+	}
+	// singlePattern's one pass is a verified random pass.
+	if err := shredFile(fsys, filename, singlePattern{}, rnd); err == nil {
+		t.Fatal("expected a verify failure when storage doesn't retain written bytes")
+	}
+}
+
+func testTrim(t *testing.T, impl conformanceImpl) {
+	fsys := impl.newFS(t, 10*1024*1024)
+
+	if err := runTrim(fsys, "."); err != nil {
+		if impl.real {
+			t.Skipf("TRIM not supported in this environment: %v", err)
+		}
+		t.Fatalf("runTrim: %v", err)
+	}
+
+	if mem, ok := fsys.(*vfs.MemFS); ok && mem.TrimCalls() != 1 {
+		t.Fatalf("TrimCalls = %d, want 1", mem.TrimCalls())
+	}
+}
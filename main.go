@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/poku-e/makeChaff/platform"
+	"github.com/poku-e/makeChaff/vfs"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(1)
+	}
+
+	cmd, args := os.Args[1], os.Args[2:]
+	pfs := platform.New()
+	fsys := vfs.NewUnixFS(pfs)
+
+	var err error
+	switch cmd {
+	case "fill":
+		err = cmdFill(fsys, pfs, args)
+	case "shred":
+		err = cmdShred(fsys, pfs, args)
+	case "trim":
+		err = cmdTrim(fsys, args)
+	case "-h", "--help", "help":
+		printUsage()
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "%s: unknown command %q\n\n", progName, cmd)
+		printUsage()
+		os.Exit(1)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", progName, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,43 @@
+// Package platform abstracts the OS-level file and disk primitives
+// makeChaff needs, so the chaff/shred/trim logic in the main package runs
+// unchanged on Unix (direct syscalls) and Windows (the equivalent Win32
+// APIs), rather than being sprinkled with build tags of its own.
+package platform
+
+// OpenFlag mirrors the handful of open(2)-style flags makeChaff uses,
+// translated by each FS implementation into its platform's native flags.
+type OpenFlag int
+
+const (
+	O_RDONLY OpenFlag = 1 << iota
+	O_WRONLY
+	O_RDWR
+	O_CREAT
+	O_TRUNC
+)
+
+// Stat is the subset of file metadata makeChaff needs.
+type Stat struct {
+	Size int64
+}
+
+// FS abstracts the filesystem and disk-management primitives used by
+// generateChaffFile, shredFile and runTrim.
+type FS interface {
+	Mkdir(path string, mode uint32) error
+	Open(path string, flag OpenFlag, mode uint32) (fd int, err error)
+	Write(fd int, buf []byte) (int, error)
+	Pread(fd int, buf []byte, offset int64) (int, error)
+	Seek(fd int, offset int64, whence int) (int64, error)
+	Fsync(fd int) error
+	Close(fd int) error
+	Unlink(path string) error
+	Stat(fd int) (Stat, error)
+	AvailableSpace(path string) (uint64, error)
+	Trim(path string) error
+
+	// ReadRandom fills buf with cryptographically random bytes, using
+	// whatever source the platform exposes (/dev/urandom on Unix,
+	// CryptGenRandom on Windows).
+	ReadRandom(buf []byte) error
+}
@@ -0,0 +1,122 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package platform
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+type unixFS struct {
+	randOnce sync.Once
+	randFd   int
+	randErr  error
+}
+
+// New returns the FS implementation for this platform.
+func New() FS { return &unixFS{} }
+
+func (*unixFS) Mkdir(path string, mode uint32) error {
+	return unix.Mkdir(path, mode)
+}
+
+func (*unixFS) Open(path string, flag OpenFlag, mode uint32) (int, error) {
+	uflag := unix.O_RDONLY
+	switch {
+	case flag&O_RDWR != 0:
+		uflag = unix.O_RDWR
+	case flag&O_WRONLY != 0:
+		uflag = unix.O_WRONLY
+	}
+	if flag&O_CREAT != 0 {
+		uflag |= unix.O_CREAT
+	}
+	if flag&O_TRUNC != 0 {
+		uflag |= unix.O_TRUNC
+	}
+	return unix.Open(path, uflag, mode)
+}
+
+func (*unixFS) Write(fd int, buf []byte) (int, error) { return unix.Write(fd, buf) }
+
+func (*unixFS) Pread(fd int, buf []byte, offset int64) (int, error) {
+	return unix.Pread(fd, buf, offset)
+}
+
+func (*unixFS) Seek(fd int, offset int64, whence int) (int64, error) {
+	return unix.Seek(fd, offset, whence)
+}
+
+func (*unixFS) Fsync(fd int) error       { return unix.Fsync(fd) }
+func (*unixFS) Close(fd int) error       { return unix.Close(fd) }
+func (*unixFS) Unlink(path string) error { return unix.Unlink(path) }
+
+func (*unixFS) Stat(fd int) (Stat, error) {
+	var st unix.Stat_t
+	if err := unix.Fstat(fd, &st); err != nil {
+		return Stat{}, err
+	}
+	return Stat{Size: st.Size}, nil
+}
+
+func (*unixFS) AvailableSpace(path string) (uint64, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
+
+func (*unixFS) Trim(path string) error {
+	switch runtime.GOOS {
+	case "linux":
+		const fitrim = 0xC0185879
+		type fstrimRange struct {
+			Start  uint64
+			Len    uint64
+			Minlen uint64
+		}
+		fd, err := unix.Open(path, unix.O_RDONLY, 0)
+		if err != nil {
+			return err
+		}
+		defer unix.Close(fd)
+
+		rng := fstrimRange{Start: 0, Len: ^uint64(0), Minlen: 0}
+		_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), fitrim, uintptr(unsafe.Pointer(&rng)))
+		if errno != 0 {
+			return fmt.Errorf("ioctl FITRIM failed: %v", errno)
+		}
+		return nil
+
+	case "darwin":
+		return fmt.Errorf("TRIM/discard must be performed manually on macOS, e.g.: sudo diskutil secureErase freespace 0 %s", path)
+
+	default:
+		return fmt.Errorf("TRIM/discard not supported for OS: %s", runtime.GOOS)
+	}
+}
+
+func (f *unixFS) ReadRandom(buf []byte) error {
+	f.randOnce.Do(func() {
+		f.randFd, f.randErr = unix.Open("/dev/urandom", unix.O_RDONLY, 0)
+	})
+	if f.randErr != nil {
+		return f.randErr
+	}
+
+	total := 0
+	for total < len(buf) {
+		n, err := unix.Read(f.randFd, buf[total:])
+		if err != nil {
+			return err
+		}
+		total += n
+	}
+	return nil
+}
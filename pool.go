@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/sync/semaphore"
+
+	"github.com/poku-e/makeChaff/vfs"
+)
+
+// reserveSlice atomically claims up to want bytes from remaining and returns
+// the amount actually claimed (0 once the budget is exhausted). Workers call
+// this before dispatch so they never race each other past the space that
+// Statfs observed up front.
+func reserveSlice(remaining *atomic.Uint64, want uint64) uint64 {
+	for {
+		cur := remaining.Load()
+		if cur == 0 {
+			return 0
+		}
+		take := want
+		if take > cur {
+			take = cur
+		}
+		if remaining.CompareAndSwap(cur, cur-take) {
+			return take
+		}
+	}
+}
+
+// fillConcurrent is the worker-pool counterpart to fill: it dispatches up to
+// concurrency generateChaffFile calls in parallel, each against its own
+// chaff_NNNNNN.dat, until the reserved free-space budget is exhausted, then
+// shreds everything it created.
+func fillConcurrent(fsys vfs.FS, outputDir string, fileSizeMB int64, filePrefix string, reserveBytes uint64, concurrency int, pattern ShredPattern, newRand RandFactory) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	if err := fsys.Mkdir(outputDir, 0755); err != nil && !os.IsExist(err) {
+		return fmt.Errorf("creating output directory: %w", err)
+	}
+
+	sf, err := fsys.Statfs(outputDir)
+	if err != nil {
+		return fmt.Errorf("getting disk space: %w", err)
+	}
+	total := sf.AvailableBytes
+	var budget uint64
+	if total > reserveBytes {
+		budget = total - reserveBytes
+	}
+
+	fmt.Printf("\n%s %s %s %d\n", colorize("Starting with", ColorCyan), formatBytes(budget), colorize("workers:", ColorCyan), concurrency)
+	fmt.Println(colorize("Generating chaff files...", ColorCyan))
+	for i := 0; i < concurrency; i++ {
+		fmt.Println()
+	}
+
+	remaining := &atomic.Uint64{}
+	remaining.Store(budget)
+	sizeBytes := uint64(fileSizeMB) * 1024 * 1024
+
+	sem := semaphore.NewWeighted(int64(concurrency))
+	ctx := context.Background()
+
+	// slots hands out which of the concurrency progress rows a worker owns.
+	// sem.Acquire only bounds how many workers are in flight at once, not
+	// which ones - workers finish out of dispatch order, so deriving a row
+	// from fileCount would let two live workers share a row and corrupt
+	// each other's progress line. Each worker returns its slot here when
+	// done, so the next dispatch reuses a row that's actually free.
+	slots := make(chan int, concurrency)
+	for i := 0; i < concurrency; i++ {
+		slots <- i
+	}
+
+	var (
+		mu      sync.Mutex
+		created []string
+		wg      sync.WaitGroup
+	)
+
+	fileCount := 0
+	for {
+		reserved := reserveSlice(remaining, sizeBytes)
+		if reserved == 0 {
+			break
+		}
+
+		if err := sem.Acquire(ctx, 1); err != nil {
+			break
+		}
+
+		slot := <-slots
+		filename := filepath.Join(outputDir, fmt.Sprintf("%s%06d.dat", filePrefix, fileCount))
+		fileCount++
+
+		wg.Add(1)
+		go func(filename string, reserved uint64, slot int) {
+			defer wg.Done()
+			defer sem.Release(1)
+			defer func() { slots <- slot }()
+
+			rnd, err := newRand()
+			if err != nil {
+				fmt.Printf("%s %s: %v\n", colorize("Error creating", ColorRed), filename, err)
+				return
+			}
+			defer rnd.Close()
+
+			_, written, err := generateChaffFile(fsys, filename, fileSizeMB, reserved, slot, concurrency, rnd)
+			if err != nil {
+				fmt.Printf("%s %s: %v\n", colorize("Error creating", ColorRed), filename, err)
+				return
+			}
+			if written > 0 {
+				mu.Lock()
+				created = append(created, filename)
+				mu.Unlock()
+			}
+		}(filename, reserved, slot)
+	}
+
+	wg.Wait()
+	fmt.Println()
+
+	fmt.Printf("\n%s\n", colorize("=== Generation Complete ===", ColorCyan))
+	fmt.Printf("%s %d\n", colorize("Files created:", ColorCyan), fileCount)
+
+	if len(created) == 0 {
+		fmt.Println(colorize("No files to shred.", ColorYellow))
+		return nil
+	}
+
+	rnd, err := newRand()
+	if err != nil {
+		return fmt.Errorf("initializing random source: %w", err)
+	}
+	defer rnd.Close()
+
+	fmt.Println()
+	fmt.Println(colorize("Shredding chaff files...", ColorRed))
+	shredFiles(fsys, created, pattern, rnd)
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/poku-e/makeChaff/platform"
+	"github.com/poku-e/makeChaff/vfs"
+)
+
+const (
+	benchFileSizeMB = 8
+	benchNumFiles   = 4
+)
+
+// benchmarkFill reserves just enough free space for benchNumFiles files of
+// benchFileSizeMB each (via --reserve-bytes) so the run is bounded regardless
+// of how much space the host actually has free, then lets fill/fillConcurrent
+// write and immediately shred them.
+func benchmarkFill(b *testing.B, concurrency int) {
+	dir, err := os.MkdirTemp("", "chaff-bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	pfs := platform.New()
+	fsys := vfs.NewUnixFS(pfs)
+	budget := uint64(benchFileSizeMB*benchNumFiles) * 1024 * 1024
+	b.SetBytes(int64(budget))
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		sub := filepath.Join(dir, fmt.Sprintf("run%d", i))
+
+		sf, err := fsys.Statfs(dir)
+		if err != nil {
+			b.Fatal(err)
+		}
+		var reserve uint64
+		if sf.AvailableBytes > budget {
+			reserve = sf.AvailableBytes - budget
+		}
+
+		newRand := func() (RandSource, error) { return NewChaCha20Source(pfs, 0) }
+		if concurrency <= 1 {
+			err = fill(fsys, sub, benchFileSizeMB, "bench_", reserve, singlePattern{}, newRand)
+		} else {
+			err = fillConcurrent(fsys, sub, benchFileSizeMB, "bench_", reserve, concurrency, singlePattern{}, newRand)
+		}
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFillSerial(b *testing.B) {
+	benchmarkFill(b, 1)
+}
+
+func BenchmarkFillConcurrent(b *testing.B) {
+	benchmarkFill(b, runtime.NumCPU())
+}
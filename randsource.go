@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/chacha20"
+
+	"github.com/poku-e/makeChaff/platform"
+)
+
+// defaultRekeyBytes is how much keystream a ChaCha20Source produces before
+// drawing a fresh key from the platform's random source.
+const defaultRekeyBytes = 16 * 1024 * 1024 * 1024 // 16 GiB
+
+// RandSource fills a buffer with bytes suitable for chaff/shred passes.
+// Implementations are not safe for concurrent use; callers that fan out
+// across workers construct one RandSource per worker.
+type RandSource interface {
+	Fill(buf []byte) error
+	Close() error
+}
+
+// RandFactory constructs a fresh RandSource, one per caller that needs its
+// own stream (e.g. one per fillConcurrent worker).
+type RandFactory func() (RandSource, error)
+
+// randSourceNames is the set of valid --rand flag values.
+var randSourceNames = map[string]bool{
+	"urandom":  true,
+	"chacha20": true,
+}
+
+// newRandSource resolves a --rand flag value to a RandSource.
+func newRandSource(fs platform.FS, name string, rekeyBytes uint64) (RandSource, error) {
+	switch name {
+	case "urandom":
+		return NewUrandomSource(fs), nil
+	case "chacha20":
+		return NewChaCha20Source(fs, rekeyBytes)
+	default:
+		return nil, fmt.Errorf("unknown rand source %q (choices: urandom, chacha20)", name)
+	}
+}
+
+// UrandomSource reads straight from the platform's random source (e.g.
+// /dev/urandom on Unix).
+type UrandomSource struct {
+	fs platform.FS
+}
+
+func NewUrandomSource(fs platform.FS) *UrandomSource {
+	return &UrandomSource{fs: fs}
+}
+
+func (s *UrandomSource) Fill(buf []byte) error { return s.fs.ReadRandom(buf) }
+func (s *UrandomSource) Close() error          { return nil }
+
+// ChaCha20Source streams a ChaCha20 keystream seeded from the platform's
+// random source, removing the per-chunk syscall that dominates
+// UrandomSource on multi-GiB fills. It is cryptographically indistinguishable
+// from the platform's random source for chaff purposes, so it's the default
+// --rand source. It rekeys itself every rekeyBytes of produced output.
+type ChaCha20Source struct {
+	urandom    *UrandomSource
+	cipher     *chacha20.Cipher
+	rekeyBytes uint64
+	produced   uint64
+}
+
+func NewChaCha20Source(fs platform.FS, rekeyBytes uint64) (*ChaCha20Source, error) {
+	if rekeyBytes == 0 {
+		rekeyBytes = defaultRekeyBytes
+	}
+
+	s := &ChaCha20Source{urandom: NewUrandomSource(fs), rekeyBytes: rekeyBytes}
+	if err := s.rekey(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *ChaCha20Source) rekey() error {
+	var key [chacha20.KeySize]byte
+	var nonce [chacha20.NonceSize]byte
+	if err := s.urandom.Fill(key[:]); err != nil {
+		return fmt.Errorf("reading chacha20 key: %w", err)
+	}
+	if err := s.urandom.Fill(nonce[:]); err != nil {
+		return fmt.Errorf("reading chacha20 nonce: %w", err)
+	}
+
+	cipher, err := chacha20.NewUnauthenticatedCipher(key[:], nonce[:])
+	if err != nil {
+		return fmt.Errorf("initializing chacha20 cipher: %w", err)
+	}
+	s.cipher = cipher
+	s.produced = 0
+	return nil
+}
+
+func (s *ChaCha20Source) Fill(buf []byte) error {
+	if s.produced+uint64(len(buf)) > s.rekeyBytes {
+		if err := s.rekey(); err != nil {
+			return err
+		}
+	}
+	for i := range buf {
+		buf[i] = 0
+	}
+	s.cipher.XORKeyStream(buf, buf)
+	s.produced += uint64(len(buf))
+	return nil
+}
+
+func (s *ChaCha20Source) Close() error { return s.urandom.Close() }
@@ -0,0 +1,152 @@
+package main
+
+import "fmt"
+
+// PassKind identifies what a single shred pass writes to disk.
+type PassKind int
+
+const (
+	// PassRandom fills the pass with cryptographically random bytes.
+	PassRandom PassKind = iota
+	// PassFixed fills the pass by tiling Pattern across each chunk.
+	PassFixed
+)
+
+// PassSpec describes one overwrite pass of a ShredPattern.
+type PassSpec struct {
+	Kind    PassKind
+	Pattern []byte // only meaningful when Kind == PassFixed
+	Verify  bool   // re-read the pass after writing and confirm it stuck
+}
+
+func (p PassSpec) describe() string {
+	if p.Kind == PassRandom {
+		return "random"
+	}
+	s := ""
+	for i, b := range p.Pattern {
+		if i > 0 {
+			s += " "
+		}
+		s += fmt.Sprintf("0x%02X", b)
+	}
+	return s
+}
+
+// ShredPattern is a named sequence of overwrite passes applied to a file
+// before it is unlinked.
+type ShredPattern interface {
+	Name() string
+	Passes() []PassSpec
+}
+
+func random() PassSpec         { return PassSpec{Kind: PassRandom} }
+func randomVerify() PassSpec   { return PassSpec{Kind: PassRandom, Verify: true} }
+func fixed(b ...byte) PassSpec { return PassSpec{Kind: PassFixed, Pattern: b} }
+
+// singlePattern is one random overwrite pass, sufficient for modern
+// journaled or SSD filesystems where magnetic remanence does not apply.
+type singlePattern struct{}
+
+func (singlePattern) Name() string       { return "single" }
+func (singlePattern) Passes() []PassSpec { return []PassSpec{randomVerify()} }
+
+// dod3Pattern is the DoD 5220.22-M 3-pass standard: a zero pass, a 0xFF
+// pass, then a verified random pass.
+type dod3Pattern struct{}
+
+func (dod3Pattern) Name() string { return "dod3" }
+func (dod3Pattern) Passes() []PassSpec {
+	return []PassSpec{
+		fixed(0x00),
+		fixed(0xFF),
+		randomVerify(),
+	}
+}
+
+// dod7Pattern is the DoD 5220.22-M (ECE) 7-pass variant.
+type dod7Pattern struct{}
+
+func (dod7Pattern) Name() string { return "dod7" }
+func (dod7Pattern) Passes() []PassSpec {
+	return []PassSpec{
+		random(),
+		fixed(0x00),
+		fixed(0xFF),
+		random(),
+		fixed(0x00),
+		fixed(0xFF),
+		randomVerify(),
+	}
+}
+
+// gutmannPattern is the classic 35-pass Gutmann method: four random passes,
+// 27 fixed patterns targeting specific magnetic encoding schemes, then four
+// more random passes.
+type gutmannPattern struct{}
+
+func (gutmannPattern) Name() string { return "gutmann" }
+func (gutmannPattern) Passes() []PassSpec {
+	passes := make([]PassSpec, 0, 35)
+	for i := 0; i < 4; i++ {
+		passes = append(passes, random())
+	}
+	passes = append(passes,
+		fixed(0x55),
+		fixed(0xAA),
+		fixed(0x92, 0x49, 0x24),
+		fixed(0x49, 0x24, 0x92),
+		fixed(0x24, 0x92, 0x49),
+	)
+	for b := 0x00; b <= 0xFF; b += 0x11 {
+		passes = append(passes, fixed(byte(b)))
+	}
+	passes = append(passes,
+		fixed(0x92, 0x49, 0x24),
+		fixed(0x49, 0x24, 0x92),
+		fixed(0x24, 0x92, 0x49),
+		fixed(0x6D, 0xB6, 0xDB),
+		fixed(0xB6, 0xDB, 0x6D),
+		fixed(0xDB, 0x6D, 0xB6),
+	)
+	for i := 0; i < 3; i++ {
+		passes = append(passes, random())
+	}
+	passes = append(passes, randomVerify())
+	return passes
+}
+
+var shredPatterns = map[string]ShredPattern{
+	"single":  singlePattern{},
+	"dod3":    dod3Pattern{},
+	"dod7":    dod7Pattern{},
+	"gutmann": gutmannPattern{},
+}
+
+// patternByName resolves a --pattern flag value to a ShredPattern.
+func patternByName(name string) (ShredPattern, error) {
+	p, ok := shredPatterns[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown shred pattern %q (choices: single, dod3, dod7, gutmann)", name)
+	}
+	return p, nil
+}
+
+// fillPass writes one pass's bytes into buf, tiling a fixed pattern to the
+// buffer boundary when the pattern is longer than a single byte.
+func fillPass(buf []byte, pass PassSpec, rnd RandSource) error {
+	switch pass.Kind {
+	case PassRandom:
+		return rnd.Fill(buf)
+	case PassFixed:
+		if len(pass.Pattern) == 0 {
+			return fmt.Errorf("fixed pass has an empty pattern")
+		}
+		for i := range buf {
+			buf[i] = pass.Pattern[i%len(pass.Pattern)]
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown pass kind %v", pass.Kind)
+	}
+}
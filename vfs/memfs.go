@@ -0,0 +1,171 @@
+package vfs
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrOutOfSpace is returned by a MemFS file's Write once writing more bytes
+// would exceed the MemFS's capacity. Like a real disk filling up mid-write,
+// it can accompany a partial (non-zero) write count rather than always
+// being returned alone.
+var ErrOutOfSpace = errors.New("vfs: out of space")
+
+// MemFS is an in-memory vfs.FS with a fixed total capacity, for exercising
+// generateChaffFile/shredFile/runTrim in tests without touching a real
+// disk. It is safe for concurrent use.
+type MemFS struct {
+	mu        sync.Mutex
+	capacity  uint64
+	used      uint64
+	files     map[string][]byte
+	trimCalls int
+}
+
+// NewMemFS returns a MemFS with the given total capacity in bytes.
+func NewMemFS(capacity uint64) *MemFS {
+	return &MemFS{capacity: capacity, files: make(map[string][]byte)}
+}
+
+func (m *MemFS) Mkdir(path string, mode uint32) error { return nil }
+
+func (m *MemFS) Create(path string, mode uint32) (File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.files[path]; ok {
+		m.used -= uint64(len(existing))
+	}
+	m.files[path] = nil
+	return &memFile{fs: m, path: path}, nil
+}
+
+func (m *MemFS) Open(path string) (File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.files[path]; !ok {
+		return nil, fmt.Errorf("vfs: open %s: no such file", path)
+	}
+	return &memFile{fs: m, path: path}, nil
+}
+
+func (m *MemFS) Unlink(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.files[path]
+	if !ok {
+		return fmt.Errorf("vfs: unlink %s: no such file", path)
+	}
+	m.used -= uint64(len(data))
+	delete(m.files, path)
+	return nil
+}
+
+func (m *MemFS) Statfs(path string) (StatfsInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return StatfsInfo{AvailableBytes: m.capacity - m.used}, nil
+}
+
+func (m *MemFS) Trim(path string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.trimCalls++
+	return nil
+}
+
+// TrimCalls reports how many times Trim has been invoked, for conformance
+// assertions.
+func (m *MemFS) TrimCalls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.trimCalls
+}
+
+type memFile struct {
+	fs   *MemFS
+	path string
+	pos  int64
+}
+
+func (f *memFile) Write(buf []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	data, ok := f.fs.files[f.path]
+	if !ok {
+		return 0, fmt.Errorf("vfs: write %s: no such file", f.path)
+	}
+
+	end := f.pos + int64(len(buf))
+	grow := int64(0)
+	if end > int64(len(data)) {
+		grow = end - int64(len(data))
+	}
+
+	n := int64(len(buf))
+	var writeErr error
+	if room := int64(f.fs.capacity - f.fs.used); grow > room {
+		if room <= 0 {
+			return 0, ErrOutOfSpace
+		}
+		n = int64(len(buf)) - (grow - room)
+		grow = room
+		writeErr = ErrOutOfSpace
+	}
+
+	newEnd := f.pos + n
+	if newEnd > int64(len(data)) {
+		data = append(data, make([]byte, newEnd-int64(len(data)))...)
+	}
+	copy(data[f.pos:newEnd], buf[:n])
+	f.fs.files[f.path] = data
+	f.fs.used += uint64(grow)
+	f.pos = newEnd
+	return int(n), writeErr
+}
+
+func (f *memFile) ReadAt(buf []byte, offset int64) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	data, ok := f.fs.files[f.path]
+	if !ok {
+		return 0, fmt.Errorf("vfs: read %s: no such file", f.path)
+	}
+	if offset >= int64(len(data)) {
+		return 0, fmt.Errorf("vfs: read %s: offset past end of file", f.path)
+	}
+	return copy(buf, data[offset:]), nil
+}
+
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	data := f.fs.files[f.path]
+	switch whence {
+	case 0:
+		f.pos = offset
+	case 1:
+		f.pos += offset
+	case 2:
+		f.pos = int64(len(data)) + offset
+	default:
+		return f.pos, fmt.Errorf("vfs: seek %s: invalid whence %d", f.path, whence)
+	}
+	return f.pos, nil
+}
+
+func (f *memFile) Fsync() error { return nil }
+func (f *memFile) Close() error { return nil }
+
+func (f *memFile) Stat() (FileInfo, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	data, ok := f.fs.files[f.path]
+	if !ok {
+		return FileInfo{}, fmt.Errorf("vfs: stat %s: no such file", f.path)
+	}
+	return FileInfo{Size: int64(len(data))}, nil
+}
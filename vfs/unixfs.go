@@ -0,0 +1,72 @@
+package vfs
+
+import "github.com/poku-e/makeChaff/platform"
+
+// UnixFS is a vfs.FS backed by a real disk, via the platform package's
+// per-OS syscalls (despite the name, this works on whichever OS
+// platform.New() targets — the name mirrors the chunk0-6 request that
+// introduced it, written back when the tool was unix-only).
+type UnixFS struct {
+	fs platform.FS
+}
+
+// NewUnixFS wraps an existing platform.FS, so callers that also need the
+// platform's random source (RandSource) share the same instance rather
+// than opening /dev/urandom twice.
+func NewUnixFS(fs platform.FS) *UnixFS { return &UnixFS{fs: fs} }
+
+func (u *UnixFS) Mkdir(path string, mode uint32) error { return u.fs.Mkdir(path, mode) }
+
+func (u *UnixFS) Create(path string, mode uint32) (File, error) {
+	fd, err := u.fs.Open(path, platform.O_CREAT|platform.O_WRONLY|platform.O_TRUNC, mode)
+	if err != nil {
+		return nil, err
+	}
+	return &unixFile{fs: u.fs, fd: fd}, nil
+}
+
+func (u *UnixFS) Open(path string) (File, error) {
+	fd, err := u.fs.Open(path, platform.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &unixFile{fs: u.fs, fd: fd}, nil
+}
+
+func (u *UnixFS) Unlink(path string) error { return u.fs.Unlink(path) }
+
+func (u *UnixFS) Statfs(path string) (StatfsInfo, error) {
+	avail, err := u.fs.AvailableSpace(path)
+	if err != nil {
+		return StatfsInfo{}, err
+	}
+	return StatfsInfo{AvailableBytes: avail}, nil
+}
+
+func (u *UnixFS) Trim(path string) error { return u.fs.Trim(path) }
+
+type unixFile struct {
+	fs platform.FS
+	fd int
+}
+
+func (f *unixFile) Write(buf []byte) (int, error) { return f.fs.Write(f.fd, buf) }
+
+func (f *unixFile) ReadAt(buf []byte, offset int64) (int, error) {
+	return f.fs.Pread(f.fd, buf, offset)
+}
+
+func (f *unixFile) Seek(offset int64, whence int) (int64, error) {
+	return f.fs.Seek(f.fd, offset, whence)
+}
+
+func (f *unixFile) Fsync() error { return f.fs.Fsync(f.fd) }
+func (f *unixFile) Close() error { return f.fs.Close(f.fd) }
+
+func (f *unixFile) Stat() (FileInfo, error) {
+	st, err := f.fs.Stat(f.fd)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	return FileInfo{Size: st.Size}, nil
+}
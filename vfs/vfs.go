@@ -0,0 +1,41 @@
+// Package vfs abstracts the filesystem primitives generateChaffFile,
+// shredFile and runTrim need, behind an interface narrow enough to be
+// backed by an in-memory implementation for tests. It sits above the
+// platform package: platform deals in raw OS handles and syscalls, vfs
+// deals in files and free space, and UnixFS bridges the two.
+package vfs
+
+// FileInfo is the subset of file metadata callers need.
+type FileInfo struct {
+	Size int64
+}
+
+// StatfsInfo is the subset of filesystem metadata callers need.
+type StatfsInfo struct {
+	AvailableBytes uint64
+}
+
+// File is an open handle to a single file under an FS.
+type File interface {
+	Write(buf []byte) (int, error)
+	ReadAt(buf []byte, offset int64) (int, error)
+	Seek(offset int64, whence int) (int64, error)
+	Fsync() error
+	Stat() (FileInfo, error)
+	Close() error
+}
+
+// FS abstracts the filesystem and disk-management primitives used by
+// generateChaffFile, shredFile and runTrim, so they can run unchanged
+// against a real disk (UnixFS) or an in-memory fake (MemFS).
+type FS interface {
+	Mkdir(path string, mode uint32) error
+	// Create opens path for writing, creating it if necessary and
+	// truncating it if it already exists.
+	Create(path string, mode uint32) (File, error)
+	// Open opens an existing path for reading and writing.
+	Open(path string) (File, error)
+	Unlink(path string) error
+	Statfs(path string) (StatfsInfo, error)
+	Trim(path string) error
+}